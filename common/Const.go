@@ -0,0 +1,32 @@
+package common
+
+const (
+	// JOB_SAVE_DIR etcd中保存任务信息的目录
+	JOB_SAVE_DIR = "/cron/jobs/"
+	// JOB_KILLER_DIR etcd中保存强杀任务信息的目录
+	JOB_KILLER_DIR = "/cron/killer/"
+	// JOB_LOCK_DIR 任务分布式锁目录
+	JOB_LOCK_DIR = "/cron/lock/"
+	// JOB_WORKER_DIR worker注册目录
+	JOB_WORKER_DIR = "/cron/workers/"
+	// JOB_SUCCESS_DIR 任务最近一次执行成功时间的共享目录，供DependsOn跨worker判断依赖是否满足
+	JOB_SUCCESS_DIR = "/cron/success/"
+)
+
+const (
+	// JOB_EVENT_SAVE 任务保存事件
+	JOB_EVENT_SAVE = 1
+	// JOB_EVENT_DELETE 任务删除事件
+	JOB_EVENT_DELETE = 2
+	// JOB_EVENT_KILLER 强杀任务事件
+	JOB_EVENT_KILLER = 3
+)
+
+const (
+	// CONCURRENCY_POLICY_ALLOW 允许并发执行
+	CONCURRENCY_POLICY_ALLOW = "Allow"
+	// CONCURRENCY_POLICY_FORBID 禁止并发执行，正在执行时跳过本次调度（默认）
+	CONCURRENCY_POLICY_FORBID = "Forbid"
+	// CONCURRENCY_POLICY_REPLACE 取消正在执行的任务，用新任务替换
+	CONCURRENCY_POLICY_REPLACE = "Replace"
+)
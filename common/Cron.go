@@ -0,0 +1,115 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gorhill/cronexpr"
+)
+
+// CronSchedule 统一的调度计划接口，屏蔽cronexpr表达式和@every/@hourly等描述符的差异
+type CronSchedule interface {
+	Next(time.Time) time.Time
+}
+
+// cronExprSchedule 基于gorhill/cronexpr的调度计划，同时支持5段和6段(带秒)表达式
+type cronExprSchedule struct {
+	expr *cronexpr.Expression
+}
+
+func (s *cronExprSchedule) Next(t time.Time) time.Time {
+	return s.expr.Next(t)
+}
+
+// everySchedule 对应"@every 5m"这种固定间隔描述符
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s *everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+// cronDescriptors 常见描述符到标准cron表达式的映射，照搬robfig/cron的约定
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// ParseCronExpr 解析任务的cron表达式，支持：
+//   - 可选的"CRON_TZ=<IANA时区名称> "前缀，指定表达式按哪个时区计算
+//   - 5段（分 时 日 月 周）和6段（秒 分 时 日 月 周）表达式
+//   - "@every 5m"/"@hourly"等描述符
+// 返回解析出的时区（未指定时为time.Local）和调度计划
+func ParseCronExpr(spec string) (loc *time.Location, schedule CronSchedule, err error) {
+	var (
+		expr     *cronexpr.Expression
+		interval time.Duration
+		fields   int
+	)
+
+	loc = time.Local
+	spec = strings.TrimSpace(spec)
+
+	// 解析 CRON_TZ=<zone> 前缀
+	if strings.HasPrefix(spec, CronTzPrefix) {
+		var (
+			rest  = strings.TrimPrefix(spec, CronTzPrefix)
+			parts = strings.SplitN(rest, " ", 2)
+		)
+		if len(parts) != 2 {
+			err = fmt.Errorf("非法的CRON_TZ表达式: %s", spec)
+			return
+		}
+		if loc, err = time.LoadLocation(parts[0]); err != nil {
+			return
+		}
+		spec = strings.TrimSpace(parts[1])
+	}
+
+	// "@every <duration>" 描述符，例如 "@every 5m"
+	if strings.HasPrefix(spec, "@every ") {
+		if interval, err = time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every "))); err != nil {
+			return
+		}
+		if interval <= 0 {
+			err = fmt.Errorf("@every 的时间间隔必须为正数: %s", spec)
+			return
+		}
+		schedule = &everySchedule{interval: interval}
+		return
+	}
+
+	// 其它 @xxx 描述符，翻译成标准cron表达式
+	if translated, ok := cronDescriptors[spec]; ok {
+		spec = translated
+	}
+
+	// cronexpr只在7段时把第一段当作秒，6段会被当作"分 时 日 月 周 年"，
+	// 所以5段补上秒位0和年份*，6段（带秒）补上年份*，统一成7段再解析
+	fields = len(strings.Fields(spec))
+	switch fields {
+	case 5:
+		spec = "0 " + spec + " *"
+	case 6:
+		spec = spec + " *"
+	}
+
+	if expr, err = cronexpr.Parse(spec); err != nil {
+		return
+	}
+	schedule = &cronExprSchedule{expr: expr}
+	return
+}
+
+// ValidateCronExpr 仅校验cron表达式（含CRON_TZ/描述符）是否合法，供master保存任务前校验使用
+func ValidateCronExpr(spec string) (err error) {
+	_, _, err = ParseCronExpr(spec)
+	return
+}
@@ -0,0 +1,99 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronExpr_FiveAndSixFields(t *testing.T) {
+	var (
+		loc      *time.Location
+		schedule CronSchedule
+		err      error
+	)
+
+	if loc, schedule, err = ParseCronExpr("*/5 * * * *"); err != nil {
+		t.Fatalf("5段表达式解析失败: %v", err)
+	}
+	if loc != time.Local {
+		t.Fatalf("未指定CRON_TZ时时区应为time.Local，实际为%v", loc)
+	}
+	if schedule == nil {
+		t.Fatal("5段表达式应返回调度计划")
+	}
+
+	if _, schedule, err = ParseCronExpr("30 */5 * * * *"); err != nil {
+		t.Fatalf("6段(带秒)表达式解析失败: %v", err)
+	}
+	if schedule == nil {
+		t.Fatal("6段表达式应返回调度计划")
+	}
+}
+
+func TestParseCronExpr_CronTzPrefix(t *testing.T) {
+	var (
+		loc *time.Location
+		err error
+	)
+
+	if loc, _, err = ParseCronExpr("CRON_TZ=Asia/Shanghai 0 */5 * * * *"); err != nil {
+		t.Fatalf("带CRON_TZ前缀的表达式解析失败: %v", err)
+	}
+	if loc.String() != "Asia/Shanghai" {
+		t.Fatalf("解析出的时区应为Asia/Shanghai，实际为%v", loc)
+	}
+
+	// 前缀后面缺少表达式
+	if _, _, err = ParseCronExpr("CRON_TZ=Asia/Shanghai"); err == nil {
+		t.Fatal("CRON_TZ后面缺少cron表达式时应报错")
+	}
+
+	// 非法的时区名称
+	if _, _, err = ParseCronExpr("CRON_TZ=Not/AZone 0 */5 * * * *"); err == nil {
+		t.Fatal("非法的时区名称应报错")
+	}
+}
+
+func TestParseCronExpr_Every(t *testing.T) {
+	var (
+		schedule CronSchedule
+		err      error
+		now      = time.Now()
+	)
+
+	if _, schedule, err = ParseCronExpr("@every 5m"); err != nil {
+		t.Fatalf("@every表达式解析失败: %v", err)
+	}
+	if next := schedule.Next(now); !next.Equal(now.Add(5 * time.Minute)) {
+		t.Fatalf("@every 5m的下次调度时间应为now+5m，实际为%v", next)
+	}
+
+	// 非法的时间间隔写法
+	if _, _, err = ParseCronExpr("@every notaduration"); err == nil {
+		t.Fatal("非法的@every间隔应报错")
+	}
+
+	// 间隔必须为正数
+	if _, _, err = ParseCronExpr("@every 0s"); err == nil {
+		t.Fatal("@every间隔为0时应报错")
+	}
+	if _, _, err = ParseCronExpr("@every -5m"); err == nil {
+		t.Fatal("@every间隔为负数时应报错")
+	}
+}
+
+func TestParseCronExpr_Descriptors(t *testing.T) {
+	var err error
+
+	for _, spec := range []string{"@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly"} {
+		if _, _, err = ParseCronExpr(spec); err != nil {
+			t.Fatalf("描述符%s应能翻译为合法的cron表达式，实际报错: %v", spec, err)
+		}
+	}
+}
+
+func TestParseCronExpr_Invalid(t *testing.T) {
+	if _, _, err := ParseCronExpr("this is not a cron expr"); err == nil {
+		t.Fatal("非法的cron表达式应报错")
+	}
+}
@@ -0,0 +1,10 @@
+package common
+
+import "errors"
+
+var (
+	// ERR_LOCK_ALREADY_REQUIRED 锁已经被占用
+	ERR_LOCK_ALREADY_REQUIRED = errors.New("锁已经被占用")
+	// ERR_NO_LOCAL_IP_FOUND 没有找到本机网卡IP
+	ERR_NO_LOCAL_IP_FOUND = errors.New("没有找到本机网卡IP")
+)
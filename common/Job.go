@@ -0,0 +1,159 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Job 任务信息
+type Job struct {
+	Name     string `json:"name"`     // 任务名
+	Command  string `json:"command"`  // shell命令
+	CronExpr string `json:"cronExpr"` // cron表达式
+	// ConcurrencyPolicy 并发策略："Allow" | "Forbid" | "Replace"，为空时按Forbid处理
+	ConcurrencyPolicy string `json:"concurrencyPolicy"`
+	// BackoffLimit 失败后最多重试次数，0表示不重试
+	BackoffLimit int `json:"backoffLimit"`
+	// BackoffBaseMs 重试退避基准时间（毫秒），实际等待时间为BackoffBaseMs*2^attempt，存在上限
+	BackoffBaseMs int `json:"backoffBaseMs"`
+	// ActiveDeadlineSeconds 单次执行最长允许运行的秒数，超时后自动杀死，0表示不限制
+	ActiveDeadlineSeconds int `json:"activeDeadlineSeconds"`
+	// DependsOn 依赖的上游任务名，只有这些任务都在lookback窗口内成功过，本任务才会被调度
+	DependsOn []string `json:"dependsOn"`
+	// DependencyLookbackSeconds 依赖成功时间的回溯窗口（秒），0表示使用默认值
+	DependencyLookbackSeconds int `json:"dependencyLookbackSeconds"`
+	// DependencyMaxWaitSeconds 依赖迟迟未满足时，最多等待多少秒后放弃本次调度，0表示使用默认值
+	DependencyMaxWaitSeconds int `json:"dependencyMaxWaitSeconds"`
+	// Labels 任务自身的标签，便于在master端按标签筛选/归类任务
+	Labels map[string]string `json:"labels"`
+	// NodeSelector 节点选择器，只有标签完全匹配的worker才会执行本任务；为空表示不限制
+	NodeSelector map[string]string `json:"nodeSelector"`
+	// SuccessHistoryLimit 每个任务保留的成功日志条数上限，0表示使用默认值
+	SuccessHistoryLimit int `json:"successHistoryLimit"`
+	// FailHistoryLimit 每个任务保留的失败日志条数上限，0表示使用默认值
+	FailHistoryLimit int `json:"failHistoryLimit"`
+}
+
+// CronTzPrefix CRON_TZ声明的前缀，例如 "CRON_TZ=Asia/Shanghai 0 */5 * * * *"
+const CronTzPrefix = "CRON_TZ="
+
+// JobsSchedulerPlan 任务调度计划
+type JobsSchedulerPlan struct {
+	Job      *Job           // 要调度的任务信息
+	Expr     CronSchedule   // 解析好的调度计划（cron表达式或@every等描述符）
+	Location *time.Location // 表达式生效的时区，未通过CRON_TZ指定时为time.Local
+	NextTime time.Time      // 下次调度时间
+}
+
+// JobExecuteInfo 任务执行状态
+type JobExecuteInfo struct {
+	Job        *Job               // 任务信息
+	PlanTime   time.Time          // 理论上的调度时间
+	RealTime   time.Time          // 实际的调度时间
+	CancelCtx  context.Context    // 任务command的上下文
+	CancelFunc context.CancelFunc // 用于取消command执行的cancel函数
+	Attempt    int                // 第几次尝试，0为首次执行，>0为重试
+	// Cancelled 是否被强杀(JOB_EVENT_KILLER)或ConcurrencyPolicy=Replace取代而主动终止，
+	// 调用CancelFunc前需要先置true，调度器据此区分"主动终止"和"执行失败"，前者不触发重试
+	Cancelled bool
+}
+
+// JobExecuteResult 任务执行结果
+type JobExecuteResult struct {
+	ExecuteInfo *JobExecuteInfo // 执行状态
+	Output      []byte          // 脚本输出
+	Err         error           // 脚本错误原因
+	StartTime   time.Time       // 启动时间
+	EndTime     time.Time       // 结束时间
+}
+
+// JobEvent 任务变化事件
+type JobEvent struct {
+	EventType int // SAVE, DELETE, KILLER
+	Job       *Job
+}
+
+// JobLog 任务执行日志
+type JobLog struct {
+	JobName       string `json:"jobName" bson:"jobName"`             // 任务名字
+	Command       string `json:"command" bson:"command"`             // 脚本命令
+	Err           string `json:"err" bson:"err"`                     // 脚本错误
+	Output        string `json:"output" bson:"output"`                // 脚本输出
+	PlanTime      int64  `json:"planTime" bson:"planTime"`           // 计划开始时间
+	SchedulerTime int64  `json:"schedulerTime" bson:"schedulerTime"` // 实际调度时间
+	StartTime     int64  `json:"startTime" bson:"startTime"`         // 任务执行开始时间
+	EndTime       int64  `json:"endTime" bson:"endTime"`             // 任务执行结束时间
+	Attempt       int    `json:"attempt" bson:"attempt"`             // 第几次尝试（0为首次执行，>0为重试）
+	// SuccessHistoryLimit/FailHistoryLimit 写入时任务配置的历史保留条数，供LogSink定期清理历史时使用
+	SuccessHistoryLimit int `json:"successHistoryLimit" bson:"successHistoryLimit"`
+	FailHistoryLimit    int `json:"failHistoryLimit" bson:"failHistoryLimit"`
+}
+
+// LogBatch 日志批次
+type LogBatch struct {
+	Logs []interface{}
+}
+
+// BuildJobExecuteInfo 构建执行状态信息
+func BuildJobExecuteInfo(jobPlan *JobsSchedulerPlan) (jobExecuteInfo *JobExecuteInfo) {
+	jobExecuteInfo = &JobExecuteInfo{
+		Job:      jobPlan.Job,
+		PlanTime: jobPlan.NextTime,
+		RealTime: time.Now(),
+	}
+	jobExecuteInfo.CancelCtx, jobExecuteInfo.CancelFunc = context.WithCancel(context.TODO())
+	return
+}
+
+// BuildJobExecuteInfoRetry 基于上一次失败的执行信息构建一次重试的执行状态
+func BuildJobExecuteInfoRetry(prev *JobExecuteInfo) (jobExecuteInfo *JobExecuteInfo) {
+	jobExecuteInfo = &JobExecuteInfo{
+		Job:      prev.Job,
+		PlanTime: prev.PlanTime,
+		RealTime: time.Now(),
+		Attempt:  prev.Attempt + 1,
+	}
+	jobExecuteInfo.CancelCtx, jobExecuteInfo.CancelFunc = context.WithCancel(context.TODO())
+	return
+}
+
+// BulidJobSchedulerPlan 解析cron表达式（支持CRON_TZ前缀、5/6段表达式、@every等描述符），构建任务调度计划
+func BulidJobSchedulerPlan(job *Job) (jobSchedulePlan *JobsSchedulerPlan, err error) {
+	var (
+		loc  *time.Location
+		expr CronSchedule
+	)
+
+	if loc, expr, err = ParseCronExpr(job.CronExpr); err != nil {
+		return
+	}
+
+	jobSchedulePlan = &JobsSchedulerPlan{
+		Job:      job,
+		Expr:     expr,
+		Location: loc,
+		NextTime: expr.Next(time.Now().In(loc)),
+	}
+	return
+}
+
+// UnpackJob 反序列化etcd中保存的Job
+func UnpackJob(value []byte) (ret *Job, err error) {
+	var (
+		job *Job
+	)
+	job = &Job{}
+	if err = json.Unmarshal(value, job); err != nil {
+		return
+	}
+	ret = job
+	return
+}
+
+// ExtractWorkerIP 从etcd的key中提取worker的IP
+// /cron/workers/192.168.1.1 -> 192.168.1.1
+func ExtractWorkerIP(regKey string) string {
+	return strings.TrimPrefix(regKey, JOB_WORKER_DIR)
+}
@@ -0,0 +1,24 @@
+package common
+
+import "encoding/json"
+
+// Response 统一的http应答结构
+type Response struct {
+	Errno int         `json:"errno"`
+	Msg   string      `json:"msg"`
+	Data  interface{} `json:"data"`
+}
+
+// BuildResponse 构建http应答
+func BuildResponse(errno int, msg string, data interface{}) (resp []byte, err error) {
+	var (
+		response Response
+	)
+
+	response.Errno = errno
+	response.Msg = msg
+	response.Data = data
+
+	resp, err = json.Marshal(response)
+	return
+}
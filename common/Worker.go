@@ -0,0 +1,17 @@
+package common
+
+// WorkerInfo 一个在线worker节点的信息
+type WorkerInfo struct {
+	IP     string            `json:"ip"`
+	Labels map[string]string `json:"labels"`
+}
+
+// MatchNodeSelector 判断一个worker的标签是否满足selector的全部要求，selector为空时总是匹配
+func MatchNodeSelector(selector map[string]string, labels map[string]string) bool {
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package master
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/hellochengxuyuan/crontab/common"
+)
+
+// ApiServer 任务的http接口
+type ApiServer struct {
+	httpServer *http.Server
+}
+
+var (
+	// G_apiServer 单例
+	G_apiServer *ApiServer
+)
+
+// handleJobSave 保存任务接口
+// POST job={"name": "job1", "command": "echo hello", "cronExpr": "* * * * *"}
+func handleJobSave(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		postJob string
+		job     common.Job
+		oldJob  *common.Job
+		bytes   []byte
+	)
+
+	if err = r.ParseForm(); err != nil {
+		goto ERR
+	}
+
+	// 取出表单中的job字段
+	postJob = r.PostForm.Get("job")
+
+	if err = json.Unmarshal([]byte(postJob), &job); err != nil {
+		goto ERR
+	}
+
+	// 提交前校验cron表达式（含CRON_TZ前缀、5/6段、@every等描述符），非法时区/表达式直接拒绝
+	if err = common.ValidateCronExpr(job.CronExpr); err != nil {
+		goto ERR
+	}
+
+	// 保存到etcd
+	if oldJob, err = G_jobMgr.SaveJob(&job); err != nil {
+		goto ERR
+	}
+
+	// 返回正常应答
+	if bytes, err = common.BuildResponse(0, "success", oldJob); err == nil {
+		w.Write(bytes)
+	}
+	return
+
+ERR:
+	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+		w.Write(bytes)
+	}
+}
+
+// handleWorkerList 获取在线worker列表（含标签），供前端展示和配置NodeSelector时参考
+func handleWorkerList(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		workerArr []*common.WorkerInfo
+		bytes     []byte
+	)
+
+	if workerArr, err = G_workerMgr.ListWorkers(); err != nil {
+		if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+			w.Write(bytes)
+		}
+		return
+	}
+
+	if bytes, err = common.BuildResponse(0, "success", workerArr); err == nil {
+		w.Write(bytes)
+	}
+}
+
+// handleLogPrune 手动清理某个任务某一状态下超出limit条数的历史日志
+// GET /api/log/prune?jobName=job1&failed=false&limit=10
+func handleLogPrune(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		jobName string
+		failed  bool
+		limit   int
+		deleted int64
+		bytes   []byte
+	)
+
+	jobName = r.URL.Query().Get("jobName")
+	failed = r.URL.Query().Get("failed") == "true"
+	if limit, err = strconv.Atoi(r.URL.Query().Get("limit")); err != nil {
+		if bytes, err = common.BuildResponse(-1, "limit参数必须是整数", nil); err == nil {
+			w.Write(bytes)
+		}
+		return
+	}
+
+	if deleted, err = G_logMgr.PruneLogs(jobName, failed, limit); err != nil {
+		if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+			w.Write(bytes)
+		}
+		return
+	}
+
+	if bytes, err = common.BuildResponse(0, "success", deleted); err == nil {
+		w.Write(bytes)
+	}
+}
+
+// InitApiServer 初始化服务
+func InitApiServer() (err error) {
+	var (
+		mux *http.ServeMux
+	)
+
+	mux = http.NewServeMux()
+	mux.HandleFunc("/job/save", handleJobSave)
+	mux.HandleFunc("/worker/list", handleWorkerList)
+	mux.HandleFunc("/api/log/prune", handleLogPrune)
+
+	G_apiServer = &ApiServer{
+		httpServer: &http.Server{
+			Handler: mux,
+		},
+	}
+	return
+}
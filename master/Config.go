@@ -0,0 +1,19 @@
+package master
+
+// Config master进程配置
+type Config struct {
+	ApiPort         int      `json:"apiPort"`
+	ApiReadTimeout  int      `json:"apiReadTimeout"`
+	ApiWriteTimeout int      `json:"apiWriteTimeout"`
+	EtcdEndPoint    []string `json:"etcdEndPoint"`
+	EtcdDialTimeout int      `json:"etcdDialTimeout"`
+	WebRoot         string   `json:"webRoot"`
+	// MongodbUri/MongodbConnectTimeout 供LogMgr查询/清理任务日志使用
+	MongodbUri            string `json:"mongodbUri"`
+	MongodbConnectTimeout int    `json:"mongodbConnectTimeout"`
+}
+
+var (
+	// G_config 单例
+	G_config *Config
+)
@@ -0,0 +1,88 @@
+package master
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/hellochengxuyuan/crontab/common"
+)
+
+// JobMgr 任务管理
+type JobMgr struct {
+	client *clientv3.Client
+	kv     clientv3.KV
+	lease  clientv3.Lease
+}
+
+var (
+	// G_jobMgr 单例
+	G_jobMgr *JobMgr
+)
+
+// SaveJob 保存任务到etcd，返回上一次保存的任务（如果存在）
+func (jobMgr *JobMgr) SaveJob(job *common.Job) (oldJob *common.Job, err error) {
+	var (
+		jobKey    string
+		jobValue  []byte
+		putResp   *clientv3.PutResponse
+		oldJobObj common.Job
+	)
+
+	// 默认并发策略为Forbid，保持向后兼容
+	if job.ConcurrencyPolicy == "" {
+		job.ConcurrencyPolicy = common.CONCURRENCY_POLICY_FORBID
+	}
+
+	// etcd的保存key
+	jobKey = common.JOB_SAVE_DIR + job.Name
+
+	// 任务信息json
+	if jobValue, err = json.Marshal(job); err != nil {
+		return
+	}
+
+	// 保存到etcd，带上WithPrevKV以便取出旧值
+	if putResp, err = jobMgr.kv.Put(context.TODO(), jobKey, string(jobValue), clientv3.WithPrevKV()); err != nil {
+		return
+	}
+
+	// 如果是更新，返回旧值
+	if putResp.PrevKv != nil {
+		if err = json.Unmarshal(putResp.PrevKv.Value, &oldJobObj); err == nil {
+			oldJob = &oldJobObj
+		}
+		err = nil
+	}
+	return
+}
+
+// InitJobMgr 初始化任务管理器
+func InitJobMgr() (err error) {
+	var (
+		config clientv3.Config
+		client *clientv3.Client
+		kv     clientv3.KV
+		lease  clientv3.Lease
+	)
+
+	config = clientv3.Config{
+		Endpoints:   G_config.EtcdEndPoint,
+		DialTimeout: time.Duration(G_config.EtcdDialTimeout) * time.Millisecond,
+	}
+
+	if client, err = clientv3.New(config); err != nil {
+		return
+	}
+
+	kv = clientv3.NewKV(client)
+	lease = clientv3.NewLease(client)
+
+	G_jobMgr = &JobMgr{
+		client: client,
+		kv:     kv,
+		lease:  lease,
+	}
+	return
+}
@@ -0,0 +1,87 @@
+package master
+
+import (
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/clientopt"
+	"github.com/mongodb/mongo-go-driver/mongo/findopt"
+	"golang.org/x/net/context"
+)
+
+// LogMgr 供master查询/清理MongoDB里保存的任务日志
+type LogMgr struct {
+	client        *mongo.Client
+	logCollection *mongo.Collection
+}
+
+var (
+	// G_logMgr 单例
+	G_logMgr *LogMgr
+)
+
+// PruneLogs 手动清理一个任务某种状态（成功/失败）下超过limit条数的旧日志，返回删除的条数
+func (logMgr *LogMgr) PruneLogs(jobName string, failed bool, limit int) (deleted int64, err error) {
+	var (
+		filter bson.M
+		count  int64
+	)
+
+	if failed {
+		filter = bson.M{"jobName": jobName, "err": bson.M{"$ne": ""}}
+	} else {
+		filter = bson.M{"jobName": jobName, "err": ""}
+	}
+
+	if count, err = logMgr.logCollection.Count(context.TODO(), filter); err != nil || limit < 0 || int64(limit) >= count {
+		return
+	}
+
+	var cursor mongo.Cursor
+	if cursor, err = logMgr.logCollection.Find(context.TODO(), filter,
+		findopt.Sort(bson.M{"startTime": 1}), findopt.Limit(count-int64(limit))); err != nil {
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var ids bson.Array
+	for cursor.Next(context.TODO()) {
+		var doc bson.M
+		if err = cursor.Decode(&doc); err != nil {
+			continue
+		}
+		if id, ok := doc["_id"]; ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	var delResp *mongo.DeleteResult
+	if delResp, err = logMgr.logCollection.DeleteMany(context.TODO(), bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return
+	}
+	deleted = delResp.DeletedCount
+	return
+}
+
+// InitLogMgr 初始化日志管理器
+func InitLogMgr() (err error) {
+	var (
+		client *mongo.Client
+	)
+
+	if client, err = mongo.Connect(context.TODO(),
+		G_config.MongodbUri,
+		clientopt.ConnectTimeout(time.Duration(G_config.MongodbConnectTimeout)*time.Millisecond)); err != nil {
+		return
+	}
+
+	G_logMgr = &LogMgr{
+		client:        client,
+		logCollection: client.Database("cron").Collection("log"),
+	}
+	return
+}
@@ -1,6 +1,8 @@
 package master
 
 import (
+	"encoding/json"
+
 	"github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/mvcc/mvccpb"
 	"github.com/hellochengxuyuan/crontab/common"
@@ -18,15 +20,15 @@ var (
 	G_workerMgr *WorkerMgr
 )
 
-// 获取在线worker列表
-func (workerMgr *WorkerMgr) ListWorkers() (workerArr []string, err error) {
+// 获取在线worker列表，包含每个worker注册时上报的标签
+func (workerMgr *WorkerMgr) ListWorkers() (workerArr []*common.WorkerInfo, err error) {
 	var (
-		getResp  *clientv3.GetResponse
-		kv       *mvccpb.KeyValue
-		workerIP string
+		getResp *clientv3.GetResponse
+		kv      *mvccpb.KeyValue
+		worker  *common.WorkerInfo
 	)
 	// 初始化数组
-	workerArr = make([]string, 0)
+	workerArr = make([]*common.WorkerInfo, 0)
 
 	// 获取目录下所有kv
 	if getResp, err = workerMgr.kv.Get(context.TODO(),
@@ -34,11 +36,18 @@ func (workerMgr *WorkerMgr) ListWorkers() (workerArr []string, err error) {
 		return
 	}
 
-	//  解析每个节点的IP
+	//  解析每个节点的IP和标签
 	for _, kv = range getResp.Kvs {
 		//  kv.key:  /cron/workers/192.168.1.1
-		workerIP = common.ExtractWorkerIP(string(kv.Key))
-		workerArr = append(workerArr, workerIP)
+		//  kv.value: {"region":"cn-east","gpu":"true"}，老版本worker可能写入空值
+		worker = &common.WorkerInfo{
+			IP: common.ExtractWorkerIP(string(kv.Key)),
+		}
+		if len(kv.Value) > 0 {
+			// 标签解析失败不影响worker本身上线，忽略错误即可
+			json.Unmarshal(kv.Value, &worker.Labels)
+		}
+		workerArr = append(workerArr, worker)
 	}
 	return
 }
@@ -0,0 +1,31 @@
+package worker
+
+// Config worker进程配置
+type Config struct {
+	EtcdEndPoint          []string `json:"etcdEndPoint"`
+	EtcdDialTimeout       int      `json:"etcdDialTimeout"`
+	MongodbUri            string   `json:"mongodbUri"`
+	MongodbConnectTimeout int      `json:"mongodbConnectTimeout"`
+	JobLogBatchSize       int      `json:"jobLogBatchSize"`
+	JobLogCommitTimeout   int      `json:"jobLogCommitTimeout"`
+	// LogSinkType 日志存储后端："mongo"（默认）| "elastic" | "kafka"
+	LogSinkType string `json:"logSinkType"`
+	// LogSinkTypes 配置多个时，日志会同时fan-out写入这里列出的每个后端（通过MultiSink包装），优先于LogSinkType
+	LogSinkTypes []string `json:"logSinkTypes"`
+	// LogRetentionDays MongoDB日志collection上TTL索引的过期天数，0表示不设置TTL
+	LogRetentionDays int `json:"logRetentionDays"`
+	// DefaultSuccessHistoryLimit/DefaultFailHistoryLimit 任务未设置对应字段时使用的默认保留条数
+	DefaultSuccessHistoryLimit int      `json:"defaultSuccessHistoryLimit"`
+	DefaultFailHistoryLimit    int      `json:"defaultFailHistoryLimit"`
+	ElasticUrl                 string   `json:"elasticUrl"`
+	ElasticIndex               string   `json:"elasticIndex"`
+	KafkaBrokers               []string `json:"kafkaBrokers"`
+	KafkaTopic                 string   `json:"kafkaTopic"`
+	// Labels 本机标签，注册到etcd供master列出，调度时本机用它匹配任务的NodeSelector，例如{"region":"cn-east","gpu":"true"}
+	Labels map[string]string `json:"labels"`
+}
+
+var (
+	// G_config 单例
+	G_config *Config
+)
@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/hellochengxuyuan/crontab/common"
+	"golang.org/x/net/context"
+)
+
+// DependencyMgr 把每个任务最近一次执行成功的时间写入etcd，所有worker共享同一份状态，
+// 使DependsOn的判断不依赖于某个worker进程本地的内存，而是哪个worker执行成功都能被下游看到
+type DependencyMgr struct {
+	client *clientv3.Client
+	kv     clientv3.KV
+}
+
+// dependencyQueryTimeout 给MarkSuccess/LastSuccess的etcd请求设置的超时。dependenciesReady在
+// schedulerLoop这个唯一的调度协程里同步调用LastSuccess，etcd卡住或不可达时不能让它无限阻塞，
+// 否则整个worker上所有任务的调度都会被这一次依赖查询拖死
+const dependencyQueryTimeout = 3 * time.Second
+
+var (
+	// G_dependencyMgr 单例
+	G_dependencyMgr *DependencyMgr
+)
+
+// MarkSuccess 记录某个任务本次执行成功的时间，写入etcd供其它worker上的DependsOn判断读取
+func (dependencyMgr *DependencyMgr) MarkSuccess(jobName string, succeededAt time.Time) {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	ctx, cancel = context.WithTimeout(context.TODO(), dependencyQueryTimeout)
+	defer cancel()
+
+	var key = common.JOB_SUCCESS_DIR + jobName
+	dependencyMgr.kv.Put(ctx, key, strconv.FormatInt(succeededAt.UnixNano(), 10))
+}
+
+// LastSuccess 查询某个任务最近一次被记录的成功时间，没有任何worker记录过时ok返回false
+func (dependencyMgr *DependencyMgr) LastSuccess(jobName string) (succeededAt time.Time, ok bool) {
+	var (
+		getResp *clientv3.GetResponse
+		nano    int64
+		err     error
+		ctx     context.Context
+		cancel  context.CancelFunc
+	)
+
+	ctx, cancel = context.WithTimeout(context.TODO(), dependencyQueryTimeout)
+	defer cancel()
+
+	if getResp, err = dependencyMgr.kv.Get(ctx, common.JOB_SUCCESS_DIR+jobName); err != nil || len(getResp.Kvs) == 0 {
+		return
+	}
+	if nano, err = strconv.ParseInt(string(getResp.Kvs[0].Value), 10, 64); err != nil {
+		return
+	}
+	succeededAt = time.Unix(0, nano)
+	ok = true
+	return
+}
+
+// InitDependencyMgr 初始化依赖状态管理器，复用与Register相同的etcd连接参数
+func InitDependencyMgr() (err error) {
+	var (
+		config clientv3.Config
+		client *clientv3.Client
+	)
+
+	config = clientv3.Config{
+		Endpoints:   G_config.EtcdEndPoint,
+		DialTimeout: time.Duration(G_config.EtcdDialTimeout) * time.Millisecond,
+	}
+
+	if client, err = clientv3.New(config); err != nil {
+		return
+	}
+
+	G_dependencyMgr = &DependencyMgr{
+		client: client,
+		kv:     clientv3.NewKV(client),
+	}
+	return
+}
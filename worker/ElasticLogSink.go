@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/hellochengxuyuan/crontab/common"
+)
+
+// ElasticLogSink 把任务日志通过Elasticsearch的_bulk接口批量写入
+type ElasticLogSink struct {
+	url       string // 例如 http://127.0.0.1:9200
+	index     string
+	logChan   chan *common.JobLog
+	closeChan chan struct{}
+	client    *http.Client
+}
+
+// saveLogs 用_bulk接口一次性索引一批日志
+func (logSink *ElasticLogSink) saveLogs(batch *common.LogBatch) {
+	var (
+		body bytes.Buffer
+		meta []byte
+		doc  []byte
+		err  error
+	)
+
+	for _, log := range batch.Logs {
+		if meta, err = json.Marshal(map[string]interface{}{
+			"index": map[string]string{"_index": logSink.index},
+		}); err != nil {
+			continue
+		}
+		if doc, err = json.Marshal(log); err != nil {
+			continue
+		}
+		body.Write(meta)
+		body.WriteByte('\n')
+		body.Write(doc)
+		body.WriteByte('\n')
+	}
+
+	resp, err := logSink.client.Post(logSink.url+"/_bulk", "application/x-ndjson", &body)
+	if err != nil {
+		fmt.Println("写入Elasticsearch失败: ", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// Append 发送日志
+func (logSink *ElasticLogSink) Append(jobLog *common.JobLog) {
+	select {
+	case logSink.logChan <- jobLog:
+	default:
+		// 队列满就丢弃
+	}
+}
+
+// Close 停止写入协程
+func (logSink *ElasticLogSink) Close() {
+	close(logSink.closeChan)
+}
+
+// initElasticLogSink 启动Elasticsearch批量写入协程
+func initElasticLogSink() (logSink *ElasticLogSink, err error) {
+	logSink = &ElasticLogSink{
+		url:       G_config.ElasticUrl,
+		index:     G_config.ElasticIndex,
+		logChan:   make(chan *common.JobLog, 1000),
+		closeChan: make(chan struct{}),
+		client:    &http.Client{},
+	}
+
+	go runBatchWriteLoop(logSink.logChan, logSink.closeChan, logSink.saveLogs)
+	return
+}
@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"context"
+	"os/exec"
+	"time"
+
+	"github.com/hellochengxuyuan/crontab/common"
+)
+
+// Executor 任务执行器
+type Executor struct {
+}
+
+var (
+	// G_executor 单例
+	G_executor *Executor
+)
+
+// ExecuteJob 执行一个任务
+func (executor *Executor) ExecuteJob(info *common.JobExecuteInfo) {
+	go func() {
+		var (
+			cmd    *exec.Cmd
+			output []byte
+			err    error
+			result *common.JobExecuteResult
+			ctx    context.Context
+			cancel context.CancelFunc
+		)
+
+		result = &common.JobExecuteResult{
+			ExecuteInfo: info,
+			Output:      make([]byte, 0),
+		}
+
+		// 记录任务开始时间
+		result.StartTime = time.Now()
+
+		//  如果配置了ActiveDeadlineSeconds，叠加一个超时，到期自动杀死任务
+		ctx = info.CancelCtx
+		if info.Job.ActiveDeadlineSeconds > 0 {
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(info.Job.ActiveDeadlineSeconds)*time.Second)
+			defer cancel()
+		}
+
+		// 执行shell命令
+		cmd = exec.CommandContext(ctx, "/bin/bash", "-c", info.Job.Command)
+
+		// 执行并捕获输出
+		output, err = cmd.CombinedOutput()
+
+		// 记录任务结束时间
+		result.EndTime = time.Now()
+		result.Output = output
+		result.Err = err
+
+		// 任务执行完成后，把执行结果返回给scheduler，scheduler会从executingTable中删除执行记录
+		G_scheduler.PushJobResult(result)
+	}()
+}
+
+// InitExecutor 初始化执行器
+func InitExecutor() (err error) {
+	G_executor = &Executor{}
+	return
+}
@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Shopify/sarama"
+	"github.com/hellochengxuyuan/crontab/common"
+)
+
+// KafkaLogSink 把任务日志以JSON消息的形式投递到Kafka指定topic
+type KafkaLogSink struct {
+	producer  sarama.SyncProducer
+	topic     string
+	logChan   chan *common.JobLog
+	closeChan chan struct{}
+}
+
+// saveLogs 把一批日志逐条投递到kafka
+func (logSink *KafkaLogSink) saveLogs(batch *common.LogBatch) {
+	var (
+		messages []*sarama.ProducerMessage
+		value    []byte
+		err      error
+	)
+
+	for _, log := range batch.Logs {
+		if value, err = json.Marshal(log); err != nil {
+			continue
+		}
+		messages = append(messages, &sarama.ProducerMessage{
+			Topic: logSink.topic,
+			Value: sarama.ByteEncoder(value),
+		})
+	}
+
+	if len(messages) == 0 {
+		return
+	}
+	if err = logSink.producer.SendMessages(messages); err != nil {
+		fmt.Println("写入Kafka失败: ", err)
+	}
+}
+
+// Append 发送日志
+func (logSink *KafkaLogSink) Append(jobLog *common.JobLog) {
+	select {
+	case logSink.logChan <- jobLog:
+	default:
+		// 队列满就丢弃
+	}
+}
+
+// Close 停止写入协程并关闭producer
+func (logSink *KafkaLogSink) Close() {
+	close(logSink.closeChan)
+	logSink.producer.Close()
+}
+
+// initKafkaLogSink 建立kafka producer并启动批量写入协程
+func initKafkaLogSink() (logSink *KafkaLogSink, err error) {
+	var (
+		config   = sarama.NewConfig()
+		producer sarama.SyncProducer
+	)
+
+	config.Producer.Return.Successes = true
+
+	if producer, err = sarama.NewSyncProducer(G_config.KafkaBrokers, config); err != nil {
+		return
+	}
+
+	logSink = &KafkaLogSink{
+		producer:  producer,
+		topic:     G_config.KafkaTopic,
+		logChan:   make(chan *common.JobLog, 1000),
+		closeChan: make(chan struct{}),
+	}
+
+	go runBatchWriteLoop(logSink.logChan, logSink.closeChan, logSink.saveLogs)
+	return
+}
@@ -1,44 +1,48 @@
 package worker
 
 import (
-	"github.com/hellochengxuyuan/crontab/common"
-	"github.com/mongodb/mongo-go-driver/mongo"
-	"github.com/mongodb/mongo-go-driver/mongo/clientopt"
-	"golang.org/x/net/context"
 	"time"
+
+	"github.com/hellochengxuyuan/crontab/common"
+)
+
+const (
+	// LogSinkTypeMongo 保存到MongoDB（默认）
+	LogSinkTypeMongo = "mongo"
+	// LogSinkTypeElastic 保存到Elasticsearch
+	LogSinkTypeElastic = "elastic"
+	// LogSinkTypeKafka 投递到Kafka
+	LogSinkTypeKafka = "kafka"
 )
 
-//  mongodb存储日志
-type LogSink struct {
-	client         *mongo.Client
-	logCollection  *mongo.Collection
-	logChan        chan *common.JobLog
-	autoCommitChan chan *common.LogBatch
+// LogSink 任务日志存储后端，MongoLogSink/ElasticLogSink/KafkaLogSink都实现了这个接口
+type LogSink interface {
+	// Append 异步追加一条日志，队列满时直接丢弃
+	Append(jobLog *common.JobLog)
+	// Close 停止批量写入协程；只有KafkaLogSink会同时关闭底层producer，Mongo/Elastic的连接不在此关闭
+	Close()
 }
 
 var (
-	// 单例
-	G_logSink *LogSink
+	// G_logSink 单例，可能是单个sink，也可能是MultiSink包装的多个sink
+	G_logSink LogSink
 )
 
-// 批量写入日志
-func (logSink *LogSink) saveLogs(batch *common.LogBatch) {
-	logSink.logCollection.InsertMany(context.TODO(), batch.Logs)
-}
-
-//  日志存储协程
-func (logSink *LogSink) writeLoop() {
+// runBatchWriteLoop 所有LogSink共用的批量写入循环：按JobLogBatchSize攒批，
+// 超过JobLogCommitTimeout未攒满也会强制提交，由调用方提供真正的flush实现
+func runBatchWriteLoop(logChan chan *common.JobLog, closeChan chan struct{}, flush func(*common.LogBatch)) {
 	var (
 		log          *common.JobLog
-		logBatch     *common.LogBatch //当前的批次
+		logBatch     *common.LogBatch // 当前的批次
 		commitTimer  *time.Timer
 		timeoutBatch *common.LogBatch // 超时批次
+		autoCommitChan = make(chan *common.LogBatch, 1000)
 	)
 
 	for {
 		select {
-		case log = <-logSink.logChan:
-			//  每次插入需要等待mongodb的一次请求往返，耗时可能因为网络慢花费比较长时间
+		case log = <-logChan:
+			//  每次插入需要等待一次网络往返，耗时可能因为网络慢花费比较长时间
 			if logBatch == nil {
 				logBatch = &common.LogBatch{}
 				// 让这个批次超时自动提交（如给1秒的时间）
@@ -46,7 +50,7 @@ func (logSink *LogSink) writeLoop() {
 					time.Duration(G_config.JobLogCommitTimeout)*time.Millisecond,
 					func(batch *common.LogBatch) func() {
 						return func() {
-							logSink.autoCommitChan <- batch
+							autoCommitChan <- batch
 						}
 					}(logBatch),
 				)
@@ -57,56 +61,60 @@ func (logSink *LogSink) writeLoop() {
 
 			//  如果批次满了，就立即发送
 			if len(logBatch.Logs) >= G_config.JobLogBatchSize {
-				// 发送日志
-				logSink.saveLogs(logBatch)
-				// 清空logBatch
+				flush(logBatch)
 				logBatch = nil
-				// 取消定时器
 				commitTimer.Stop()
 			}
-		case timeoutBatch = <-logSink.autoCommitChan: //过期的批次
+		case timeoutBatch = <-autoCommitChan: // 过期的批次
 			// 判断过期批次是否仍旧是当前的批次
 			if timeoutBatch != logBatch {
-				continue //  跳过已经被提交的批次
+				continue // 跳过已经被提交的批次
 			}
-			// 把这个批次写入到mongodb中
-			logSink.saveLogs(timeoutBatch)
-			// 清空logBatch
+			flush(timeoutBatch)
 			logBatch = nil
+		case <-closeChan:
+			return
 		}
 	}
 }
 
+// newLogSinkByType 按类型构造单个LogSink后端
+func newLogSinkByType(sinkType string) (LogSink, error) {
+	switch sinkType {
+	case LogSinkTypeElastic:
+		return initElasticLogSink()
+	case LogSinkTypeKafka:
+		return initKafkaLogSink()
+	default: // 包括 LogSinkTypeMongo 和空值
+		return initMongoLogSink()
+	}
+}
+
+// InitLogSink 根据配置初始化日志存储后端：配置了LogSinkTypes（多个）时，每个类型各建一个sink，
+// 用MultiSink把它们fan-out成一个LogSink；否则回退到logSinkType这个单后端的历史行为
 func InitLogSink() (err error) {
 	var (
-		client *mongo.Client
+		types []string
+		sinks []LogSink
+		sink  LogSink
 	)
 
-	// 建立mongodb连接
-	if client, err = mongo.Connect(context.TODO(),
-		G_config.MongodbUri,
-		clientopt.ConnectTimeout(time.Duration(G_config.MongodbConnectTimeout)*time.Millisecond)); err != nil {
-		return
+	types = G_config.LogSinkTypes
+	if len(types) == 0 {
+		types = []string{G_config.LogSinkType}
 	}
 
-	//  选择db和collection
-	G_logSink = &LogSink{
-		client:         client,
-		logCollection:  client.Database("cron").Collection("log"),
-		logChan:        make(chan *common.JobLog, 1000),
-		autoCommitChan: make(chan *common.LogBatch, 1000),
+	for _, sinkType := range types {
+		if sink, err = newLogSinkByType(sinkType); err != nil {
+			return
+		}
+		sinks = append(sinks, sink)
 	}
 
-	// 启动一个mongodb处理协程
-	go G_logSink.writeLoop()
-	return
-}
-
-// 发送日志
-func (logSink *LogSink) Append(jobLog *common.JobLog) {
-	select {
-	case logSink.logChan <- jobLog:
-	default:
-		// 队列满就丢弃
+	if len(sinks) == 1 {
+		G_logSink = sinks[0]
+	} else {
+		G_logSink = NewMultiSink(sinks)
 	}
+	return
 }
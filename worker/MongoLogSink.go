@@ -0,0 +1,200 @@
+package worker
+
+import (
+	"time"
+
+	"github.com/hellochengxuyuan/crontab/common"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/clientopt"
+	"github.com/mongodb/mongo-go-driver/mongo/findopt"
+	"github.com/mongodb/mongo-go-driver/mongo/indexopt"
+	"golang.org/x/net/context"
+)
+
+// PruneInterval 历史日志清理的执行间隔
+const PruneInterval = 1 * time.Minute
+
+// MongoLogSink 把任务日志批量写入MongoDB的log集合
+type MongoLogSink struct {
+	client        *mongo.Client
+	logCollection *mongo.Collection
+	logChan       chan *common.JobLog
+	closeChan     chan struct{}
+}
+
+// saveLogs 批量写入日志
+func (logSink *MongoLogSink) saveLogs(batch *common.LogBatch) {
+	logSink.logCollection.InsertMany(context.TODO(), batch.Logs)
+}
+
+// Append 发送日志
+func (logSink *MongoLogSink) Append(jobLog *common.JobLog) {
+	select {
+	case logSink.logChan <- jobLog:
+	default:
+		// 队列满就丢弃
+	}
+}
+
+// Close 停止写入协程
+func (logSink *MongoLogSink) Close() {
+	close(logSink.closeChan)
+}
+
+// pruneHistory 按JobName+成功/失败状态分组，超过SuccessHistoryLimit/FailHistoryLimit的旧日志被删除。
+// distinct先拿到任务名集合，再对每个JobName+状态桶单独聚合统计条数、定位要删除的最旧记录。
+func (logSink *MongoLogSink) pruneHistory() {
+	var (
+		jobNames []string
+		err      error
+	)
+
+	if jobNames, err = logSink.distinctJobNames(); err != nil {
+		return
+	}
+
+	for _, jobName := range jobNames {
+		logSink.pruneBucket(jobName, false, G_config.DefaultSuccessHistoryLimit)
+		logSink.pruneBucket(jobName, true, G_config.DefaultFailHistoryLimit)
+	}
+}
+
+// distinctJobNames 列出log集合中出现过的所有任务名
+func (logSink *MongoLogSink) distinctJobNames() (names []string, err error) {
+	var (
+		raw interface{}
+	)
+	if raw, err = logSink.logCollection.Distinct(context.TODO(), "jobName", bson.NewDocument()); err != nil {
+		return
+	}
+	if arr, ok := raw.([]interface{}); ok {
+		for _, v := range arr {
+			if s, ok := v.(string); ok {
+				names = append(names, s)
+			}
+		}
+	}
+	return
+}
+
+// pruneBucket 对某个任务的某一状态（成功/失败）按limit保留最新的记录，其余删除；
+// 优先使用任务自己写入时记录的SuccessHistoryLimit/FailHistoryLimit，任务没配置（<=0）时才回退到defaultLimit这个全局默认值
+func (logSink *MongoLogSink) pruneBucket(jobName string, failed bool, defaultLimit int) {
+	var (
+		filter bson.M
+		err    error
+		count  int64
+	)
+
+	if failed {
+		filter = bson.M{"jobName": jobName, "err": bson.M{"$ne": ""}}
+	} else {
+		filter = bson.M{"jobName": jobName, "err": ""}
+	}
+
+	if count, err = logSink.logCollection.Count(context.TODO(), filter); err != nil || count <= 0 {
+		return
+	}
+
+	// 这个分组里最新一条日志写入时记录的per-job限制，优先生效
+	var newest common.JobLog
+	sortOpt := findopt.Sort(bson.M{"startTime": -1})
+	if err = logSink.logCollection.FindOne(context.TODO(), filter, sortOpt).Decode(&newest); err != nil {
+		return
+	}
+	limit := newest.SuccessHistoryLimit
+	if failed {
+		limit = newest.FailHistoryLimit
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit <= 0 {
+		return // 任务自身和全局都未配置限制，不清理
+	}
+	if int64(limit) >= count {
+		return
+	}
+
+	// 按startTime升序取出最旧的 (count-limit) 条记录，收集它们的_id
+	toDelete := count - int64(limit)
+	cursor, err := logSink.logCollection.Find(context.TODO(), filter,
+		findopt.Sort(bson.M{"startTime": 1}), findopt.Limit(toDelete))
+	if err != nil {
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var ids bson.Array
+	for cursor.Next(context.TODO()) {
+		var doc bson.M
+		if err = cursor.Decode(&doc); err != nil {
+			continue
+		}
+		if id, ok := doc["_id"]; ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	logSink.logCollection.DeleteMany(context.TODO(), bson.M{"_id": bson.M{"$in": ids}})
+}
+
+// pruneLoop 每隔PruneInterval清理一次超出历史条数限制的日志
+func (logSink *MongoLogSink) pruneLoop() {
+	var ticker = time.NewTicker(PruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			logSink.pruneHistory()
+		case <-logSink.closeChan:
+			return
+		}
+	}
+}
+
+// ensureTTLIndex 按LogRetentionDays在startTime字段上创建TTL索引，使MongoDB自动淘汰过期日志
+func ensureTTLIndex(collection *mongo.Collection) {
+	if G_config.LogRetentionDays <= 0 {
+		return
+	}
+	expireAfter := time.Duration(G_config.LogRetentionDays) * 24 * time.Hour
+	collection.Indexes().CreateOne(context.TODO(),
+		mongo.IndexModel{Keys: bson.NewDocument(bson.EC.Int32("startTime", 1))},
+		indexopt.ExpireAfterSeconds(int32(expireAfter.Seconds())),
+	)
+}
+
+// initMongoLogSink 建立mongodb连接，确保TTL索引存在，并启动批量写入与历史清理协程
+func initMongoLogSink() (logSink *MongoLogSink, err error) {
+	var (
+		client *mongo.Client
+	)
+
+	// 建立mongodb连接
+	if client, err = mongo.Connect(context.TODO(),
+		G_config.MongodbUri,
+		clientopt.ConnectTimeout(time.Duration(G_config.MongodbConnectTimeout)*time.Millisecond)); err != nil {
+		return
+	}
+
+	//  选择db和collection
+	logSink = &MongoLogSink{
+		client:        client,
+		logCollection: client.Database("cron").Collection("log"),
+		logChan:       make(chan *common.JobLog, 1000),
+		closeChan:     make(chan struct{}),
+	}
+
+	// 按LogRetentionDays建立startTime上的TTL索引，让MongoDB自动淘汰过期日志
+	ensureTTLIndex(logSink.logCollection)
+
+	// 启动一个mongodb处理协程，复用公共的批量写入循环
+	go runBatchWriteLoop(logSink.logChan, logSink.closeChan, logSink.saveLogs)
+	// 启动按SuccessHistoryLimit/FailHistoryLimit清理历史日志的协程
+	go logSink.pruneLoop()
+	return
+}
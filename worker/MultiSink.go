@@ -0,0 +1,27 @@
+package worker
+
+import "github.com/hellochengxuyuan/crontab/common"
+
+// MultiSink 把同一条日志同时分发给多个LogSink，用于需要同时落地多个存储的场景
+type MultiSink struct {
+	sinks []LogSink
+}
+
+// NewMultiSink 组合多个LogSink为一个LogSink
+func NewMultiSink(sinks []LogSink) *MultiSink {
+	return &MultiSink{sinks: sinks}
+}
+
+// Append 依次转发给每个子sink
+func (m *MultiSink) Append(jobLog *common.JobLog) {
+	for _, sink := range m.sinks {
+		sink.Append(jobLog)
+	}
+}
+
+// Close 依次关闭每个子sink
+func (m *MultiSink) Close() {
+	for _, sink := range m.sinks {
+		sink.Close()
+	}
+}
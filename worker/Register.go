@@ -0,0 +1,135 @@
+package worker
+
+import (
+	"encoding/json"
+	"net"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/hellochengxuyuan/crontab/common"
+	"golang.org/x/net/context"
+)
+
+// Register 负责把本机worker注册到etcd，供master发现集群中的worker及其标签
+type Register struct {
+	client *clientv3.Client
+	kv     clientv3.KV
+	lease  clientv3.Lease
+
+	localIP string // 本机IP，从网卡中选取一个有效的IPv4地址
+}
+
+var (
+	// G_register 单例
+	G_register *Register
+)
+
+// getLocalIP 选取一个本机的非回环IPv4地址作为worker的注册身份
+func getLocalIP() (ipv4 string, err error) {
+	var (
+		addrs   []net.Addr
+		addr    net.Addr
+		ipNet   *net.IPNet
+		isIpNet bool
+	)
+
+	if addrs, err = net.InterfaceAddrs(); err != nil {
+		return
+	}
+
+	for _, addr = range addrs {
+		if ipNet, isIpNet = addr.(*net.IPNet); !isIpNet || ipNet.IP.IsLoopback() {
+			continue
+		}
+		if ipNet.IP.To4() == nil {
+			continue
+		}
+		ipv4 = ipNet.IP.String()
+		return
+	}
+
+	err = common.ERR_NO_LOCAL_IP_FOUND
+	return
+}
+
+// keepOnline 维持worker在etcd中的注册：申请租约、续租，并把本机标签写入 /cron/workers/<ip>
+func (register *Register) keepOnline() {
+	var (
+		regKey         string
+		leaseGrantResp *clientv3.LeaseGrantResponse
+		keepAliveChan  <-chan *clientv3.LeaseKeepAliveResponse
+		labelsJSON     []byte
+		err            error
+		cancelCtx      context.Context
+		cancelFunc     context.CancelFunc
+	)
+
+	regKey = common.JOB_WORKER_DIR + register.localIP
+
+	if labelsJSON, err = json.Marshal(G_config.Labels); err != nil {
+		labelsJSON = []byte("{}")
+	}
+
+	for {
+		cancelFunc = nil
+
+		// 申请租约，10秒超时自动失效
+		if leaseGrantResp, err = register.lease.Grant(context.TODO(), 10); err != nil {
+			goto RETRY
+		}
+
+		// 自动续租
+		if keepAliveChan, err = register.lease.KeepAlive(context.TODO(), leaseGrantResp.ID); err != nil {
+			goto RETRY
+		}
+
+		cancelCtx, cancelFunc = context.WithCancel(context.TODO())
+
+		// 注册到etcd，value写入本机标签的json
+		if _, err = register.kv.Put(cancelCtx, regKey, string(labelsJSON), clientv3.WithLease(leaseGrantResp.ID)); err != nil {
+			goto RETRY
+		}
+
+		// 持续消费续租应答，直到租约失效或被取消
+		for range keepAliveChan {
+		}
+
+	RETRY:
+		if cancelFunc != nil {
+			cancelFunc()
+		}
+		time.Sleep(1 * time.Second)
+	}
+}
+
+// InitRegister 初始化worker注册
+func InitRegister() (err error) {
+	var (
+		config  clientv3.Config
+		client  *clientv3.Client
+		localIP string
+	)
+
+	if localIP, err = getLocalIP(); err != nil {
+		return
+	}
+
+	config = clientv3.Config{
+		Endpoints:   G_config.EtcdEndPoint,
+		DialTimeout: time.Duration(G_config.EtcdDialTimeout) * time.Millisecond,
+	}
+
+	if client, err = clientv3.New(config); err != nil {
+		return
+	}
+
+	G_register = &Register{
+		client:  client,
+		kv:      clientv3.NewKV(client),
+		lease:   clientv3.NewLease(client),
+		localIP: localIP,
+	}
+
+	go G_register.keepOnline()
+	return
+}
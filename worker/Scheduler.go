@@ -10,10 +10,24 @@ import (
 type Scheduler struct {
 	jobEventChan      chan *common.JobEvent                //etcd任务事件队列
 	jobPlanTable      map[string]*common.JobsSchedulerPlan //  任务调度计划表
-	jobExecutingTable map[string]*common.JobExecuteInfo    //  任务执行表
+	jobExecutingTable map[string][]*common.JobExecuteInfo  //  任务执行表，一个任务名可能对应多个并发的执行（Allow策略）
 	jobResultChan     chan *common.JobExecuteResult        //  任务结果队列
+	retryChan         chan *common.JobExecuteInfo          //  任务重试队列
+	depWaitSince      map[string]time.Time                 //  任务因依赖未满足被推迟时，第一次被推迟的时间
 }
 
+// BackoffCapMs 重试退避等待时间上限（毫秒），避免BackoffBaseMs*2^attempt无限增长
+const BackoffCapMs = 10 * 60 * 1000
+
+const (
+	// DefaultDependencyLookbackSeconds 依赖默认回溯窗口
+	DefaultDependencyLookbackSeconds = 24 * 60 * 60
+	// DefaultDependencyMaxWaitSeconds 依赖默认最长等待时间
+	DefaultDependencyMaxWaitSeconds = 10 * 60
+	// DependencyRecheckInterval 依赖未满足时的重新检查间隔
+	DependencyRecheckInterval = 5 * time.Second
+)
+
 var (
 	G_scheduler *Scheduler
 )
@@ -23,6 +37,7 @@ func (scheduler *Scheduler) handleJobEvent(jobEvent *common.JobEvent) {
 	var (
 		jobSchedulerPlan *common.JobsSchedulerPlan
 		jobExecuteInfo   *common.JobExecuteInfo
+		jobExecuteInfos  []*common.JobExecuteInfo
 		jobExecuting     bool
 		jobExisted       bool
 		err              error
@@ -39,40 +54,159 @@ func (scheduler *Scheduler) handleJobEvent(jobEvent *common.JobEvent) {
 		}
 	case common.JOB_EVENT_KILLER: //强杀任务事件
 		//  取消掉common执行，首先判断任务是否在执行中
-		if jobExecuteInfo, jobExecuting = scheduler.jobExecutingTable[jobEvent.Job.Name]; jobExecuting {
-			jobExecuteInfo.CancelFunc() //  触发command杀死shell子进程，任务得到退出
+		if jobExecuteInfos, jobExecuting = scheduler.jobExecutingTable[jobEvent.Job.Name]; jobExecuting {
+			for _, jobExecuteInfo = range jobExecuteInfos {
+				jobExecuteInfo.Cancelled = true // 标记为主动终止，handleJobResult据此跳过重试
+				jobExecuteInfo.CancelFunc()      // 触发command杀死shell子进程，任务得到退出
+			}
 		}
 	}
 }
 
 // 尝试执行任务
 func (scheduler *Scheduler) TryStartJob(jobPlan *common.JobsSchedulerPlan) {
-	var (
-		jobExecuteInfo *common.JobExecuteInfo
-		jobExecuting   bool
-	)
-
 	//  调度和执行是2件事情
 
-	//  执行的任务可能运行很久，比如1分钟会调度60次，但是只能执行1次 ，防止并发
-
-	//  如果任务正在执行，跳过本次调度
-	if jobExecuteInfo, jobExecuting = scheduler.jobExecutingTable[jobPlan.Job.Name]; jobExecuting {
-		//fmt.Println("尚未退出，跳过执行: ", jobPlan.Job.Name)
+	//  节点选择：如果任务配置了NodeSelector且本机标签不满足，跳过调度，把任务留给其它符合条件的worker
+	if !common.MatchNodeSelector(jobPlan.Job.NodeSelector, G_config.Labels) {
 		return
 	}
 
 	//  构建执行状态信息
-	jobExecuteInfo = common.BuildJobExecuteInfo(jobPlan)
+	scheduler.tryStartExecution(common.BuildJobExecuteInfo(jobPlan))
+}
+
+//  按ConcurrencyPolicy决定是否启动这次执行，首次调度和到期重试都要经过这同一道门禁，
+//  否则Forbid的任务可能在重试还没跑之前就被新的一次调度提前占用了执行表，导致两次并发执行
+func (scheduler *Scheduler) tryStartExecution(jobExecuteInfo *common.JobExecuteInfo) {
+	var (
+		job             = jobExecuteInfo.Job
+		jobExecuteInfos []*common.JobExecuteInfo
+		jobExecuting    bool
+	)
+
+	//  执行的任务可能运行很久，比如1分钟会调度60次，是否允许重叠执行由ConcurrencyPolicy决定
+	jobExecuteInfos, jobExecuting = scheduler.jobExecutingTable[job.Name]
+
+	switch job.ConcurrencyPolicy {
+	case common.CONCURRENCY_POLICY_ALLOW:
+		//  允许并发，不管是否在执行中都直接启动新的一次
+	case common.CONCURRENCY_POLICY_REPLACE:
+		//  取消掉正在执行中的任务，再启动新的一次
+		if jobExecuting {
+			for _, info := range jobExecuteInfos {
+				info.Cancelled = true // 标记为主动终止，handleJobResult据此跳过重试
+				info.CancelFunc()
+			}
+			delete(scheduler.jobExecutingTable, job.Name)
+		}
+	default: //  CONCURRENCY_POLICY_FORBID 及默认值：如果任务正在执行，跳过本次调度
+		if jobExecuting {
+			//fmt.Println("尚未退出，跳过执行: ", job.Name)
+			return
+		}
+	}
+
+	scheduler.startJobExecution(jobExecuteInfo)
+}
 
+//  记录执行状态并真正启动任务（调用方需先经过tryStartExecution的ConcurrencyPolicy门禁）
+func (scheduler *Scheduler) startJobExecution(jobExecuteInfo *common.JobExecuteInfo) {
 	// 保存执行状态
-	scheduler.jobExecutingTable[jobPlan.Job.Name] = jobExecuteInfo
+	scheduler.jobExecutingTable[jobExecuteInfo.Job.Name] = append(scheduler.jobExecutingTable[jobExecuteInfo.Job.Name], jobExecuteInfo)
 
 	//  执行任务
-	fmt.Println("执行任务：", jobExecuteInfo.Job.Name, jobExecuteInfo.PlanTime, jobExecuteInfo.RealTime)
+	fmt.Println("执行任务：", jobExecuteInfo.Job.Name, jobExecuteInfo.PlanTime, jobExecuteInfo.RealTime, "尝试次数:", jobExecuteInfo.Attempt)
 	G_executor.ExecuteJob(jobExecuteInfo)
 }
 
+//  安排一次退避重试，到期后把执行信息投递到retryChan
+func (scheduler *Scheduler) scheduleRetry(jobExecuteInfo *common.JobExecuteInfo) {
+	var (
+		job       = jobExecuteInfo.Job
+		retryInfo *common.JobExecuteInfo
+		backoffMs int
+	)
+
+	if jobExecuteInfo.Attempt >= job.BackoffLimit {
+		return
+	}
+
+	// 退避时间 = BackoffBaseMs * 2^attempt，封顶BackoffCapMs（乘法溢出时也按上限处理）
+	backoffMs = job.BackoffBaseMs * (1 << uint(jobExecuteInfo.Attempt))
+	if backoffMs > BackoffCapMs || (backoffMs <= 0 && job.BackoffBaseMs > 0) {
+		backoffMs = BackoffCapMs
+	}
+
+	retryInfo = common.BuildJobExecuteInfoRetry(jobExecuteInfo)
+
+	time.AfterFunc(time.Duration(backoffMs)*time.Millisecond, func() {
+		scheduler.retryChan <- retryInfo
+	})
+}
+
+//  检查任务的上游依赖是否满足：每个DependsOn中的任务都必须在回溯窗口内成功过
+//  ready=true表示可以执行；giveUp=true表示等待已经超过DependencyMaxWaitSeconds，应放弃本次调度
+func (scheduler *Scheduler) dependenciesReady(jobPlan *common.JobsSchedulerPlan, now time.Time) (ready bool, giveUp bool) {
+	var (
+		job         = jobPlan.Job
+		depName     string
+		lookback    time.Duration
+		maxWait     time.Duration
+		waitedSince time.Time
+		waited      bool
+	)
+
+	if len(job.DependsOn) == 0 {
+		ready = true
+		return
+	}
+
+	lookback = time.Duration(job.DependencyLookbackSeconds) * time.Second
+	if lookback <= 0 {
+		lookback = DefaultDependencyLookbackSeconds * time.Second
+	}
+	maxWait = time.Duration(job.DependencyMaxWaitSeconds) * time.Second
+	if maxWait <= 0 {
+		maxWait = DefaultDependencyMaxWaitSeconds * time.Second
+	}
+
+	ready = true
+	for _, depName = range job.DependsOn {
+		// lastSuccess由DependencyMgr存在etcd里，所有worker共享，不管是哪个worker跑成功的都能看到
+		if succeededAt, ok := G_dependencyMgr.LastSuccess(depName); !ok || succeededAt.Before(now.Add(-lookback)) {
+			ready = false
+			break
+		}
+	}
+
+	if ready {
+		delete(scheduler.depWaitSince, job.Name)
+		return
+	}
+
+	if waitedSince, waited = scheduler.depWaitSince[job.Name]; !waited {
+		scheduler.depWaitSince[job.Name] = now
+		return
+	}
+
+	if now.Sub(waitedSince) >= maxWait {
+		giveUp = true
+		delete(scheduler.depWaitSince, job.Name)
+	}
+	return
+}
+
+//  记录一条"依赖未满足"的日志
+func (scheduler *Scheduler) logDependencyUnmet(jobPlan *common.JobsSchedulerPlan) {
+	G_logSink.Append(&common.JobLog{
+		JobName:  jobPlan.Job.Name,
+		Command:  jobPlan.Job.Command,
+		Err:      fmt.Sprintf("依赖未满足，放弃本次调度: %v", jobPlan.Job.DependsOn),
+		PlanTime: jobPlan.NextTime.UnixNano() / 1000 / 1000,
+	})
+}
+
 //  重新计算任务调度状态
 func (scheduler *Scheduler) TryScheduler() (schedulerAfter time.Duration) {
 	var (
@@ -93,9 +227,19 @@ func (scheduler *Scheduler) TryScheduler() (schedulerAfter time.Duration) {
 	// 遍历所有任务
 	for _, jobPlan = range scheduler.jobPlanTable {
 		if jobPlan.NextTime.Before(now) || jobPlan.NextTime.Equal(now) {
-			//  TODO:尝试执行任务
-			scheduler.TryStartJob(jobPlan)
-			jobPlan.NextTime = jobPlan.Expr.Next(now) // 更新下次执行时间
+			ready, giveUp := scheduler.dependenciesReady(jobPlan, now)
+			switch {
+			case giveUp:
+				//  依赖迟迟没满足，放弃这一次调度，记录一条日志，按正常周期排下一次
+				scheduler.logDependencyUnmet(jobPlan)
+				jobPlan.NextTime = jobPlan.Expr.Next(now.In(jobPlan.Location))
+			case ready:
+				scheduler.TryStartJob(jobPlan)
+				jobPlan.NextTime = jobPlan.Expr.Next(now.In(jobPlan.Location)) // 更新下次执行时间（按表达式声明的时区计算）
+			default:
+				//  依赖还没满足，先不执行，隔一小段时间再检查一次
+				jobPlan.NextTime = now.Add(DependencyRecheckInterval)
+			}
 		}
 
 		//  统计最近一个要过期的任务时间
@@ -112,26 +256,51 @@ func (scheduler *Scheduler) TryScheduler() (schedulerAfter time.Duration) {
 // 处理任务结果
 func (scheduler *Scheduler) handleJobResult(result *common.JobExecuteResult) {
 	var (
-		jobLog *common.JobLog
+		jobLog          *common.JobLog
+		jobExecuteInfos []*common.JobExecuteInfo
+		i               int
 	)
-	//  删除执行状态
-	delete(scheduler.jobExecutingTable, result.ExecuteInfo.Job.Name)
+	//  从执行表中摘掉这一次执行记录（Allow策略下同名任务可能有多条在跑）
+	if jobExecuteInfos = scheduler.jobExecutingTable[result.ExecuteInfo.Job.Name]; jobExecuteInfos != nil {
+		for i = range jobExecuteInfos {
+			if jobExecuteInfos[i] == result.ExecuteInfo {
+				jobExecuteInfos = append(jobExecuteInfos[:i], jobExecuteInfos[i+1:]...)
+				break
+			}
+		}
+		if len(jobExecuteInfos) == 0 {
+			delete(scheduler.jobExecutingTable, result.ExecuteInfo.Job.Name)
+		} else {
+			scheduler.jobExecutingTable[result.ExecuteInfo.Job.Name] = jobExecuteInfos
+		}
+	}
 
 	// 生成执行日志
 	if result.Err != common.ERR_LOCK_ALREADY_REQUIRED {
 		jobLog = &common.JobLog{
-			JobName:       result.ExecuteInfo.Job.Name,
-			Command:       result.ExecuteInfo.Job.Command,
-			Output:        string(result.Output),
-			PlanTime:      result.ExecuteInfo.PlanTime.UnixNano() / 1000 / 1000,
-			SchedulerTime: result.ExecuteInfo.RealTime.UnixNano() / 1000 / 1000,
-			StartTime:     result.StartTime.UnixNano() / 1000 / 1000,
-			EndTime:       result.EndTime.UnixNano() / 1000 / 1000,
+			JobName:             result.ExecuteInfo.Job.Name,
+			Command:             result.ExecuteInfo.Job.Command,
+			Output:              string(result.Output),
+			PlanTime:            result.ExecuteInfo.PlanTime.UnixNano() / 1000 / 1000,
+			SchedulerTime:       result.ExecuteInfo.RealTime.UnixNano() / 1000 / 1000,
+			StartTime:           result.StartTime.UnixNano() / 1000 / 1000,
+			EndTime:             result.EndTime.UnixNano() / 1000 / 1000,
+			Attempt:             result.ExecuteInfo.Attempt,
+			SuccessHistoryLimit: result.ExecuteInfo.Job.SuccessHistoryLimit,
+			FailHistoryLimit:    result.ExecuteInfo.Job.FailHistoryLimit,
 		}
-		if result.Err != nil {
+		switch {
+		case result.ExecuteInfo.Cancelled:
+			//  被JOB_EVENT_KILLER强杀或被ConcurrencyPolicy=Replace取代，属于主动终止，不算失败，不安排重试
+			jobLog.Err = ""
+		case result.Err != nil:
 			jobLog.Err = result.Err.Error()
-		} else {
+			//  执行失败，按BackoffLimit/BackoffBaseMs安排一次退避重试
+			scheduler.scheduleRetry(result.ExecuteInfo)
+		default:
 			jobLog.Err = ""
+			//  把这个任务最近一次成功时间写入etcd，供所有worker上依赖它的下游任务判断
+			G_dependencyMgr.MarkSuccess(result.ExecuteInfo.Job.Name, result.EndTime)
 		}
 		//  TODO: 存储到Mongodb
 		G_logSink.Append(jobLog)
@@ -143,10 +312,11 @@ func (scheduler *Scheduler) handleJobResult(result *common.JobExecuteResult) {
 //  调度协程
 func (scheduler *Scheduler) schedulerLoop() {
 	var (
-		jobEvent       *common.JobEvent
-		schedulerAfter time.Duration
-		schedulerTimer *time.Timer
-		jobResult      *common.JobExecuteResult
+		jobEvent         *common.JobEvent
+		schedulerAfter   time.Duration
+		schedulerTimer   *time.Timer
+		jobResult        *common.JobExecuteResult
+		retryExecuteInfo *common.JobExecuteInfo
 	)
 
 	// 初始化一次(1秒)
@@ -164,6 +334,8 @@ func (scheduler *Scheduler) schedulerLoop() {
 		case <-schedulerTimer.C: // 最近的任务到期了
 		case jobResult = <-scheduler.jobResultChan: // 监听任务执行结果
 			scheduler.handleJobResult(jobResult)
+		case retryExecuteInfo = <-scheduler.retryChan: // 到期的重试任务，同样要经过ConcurrencyPolicy门禁
+			scheduler.tryStartExecution(retryExecuteInfo)
 		}
 		// 调度一次任务
 		schedulerAfter = scheduler.TryScheduler()
@@ -182,8 +354,10 @@ func InitScheduler() (err error) {
 	G_scheduler = &Scheduler{
 		jobEventChan:      make(chan *common.JobEvent, 1000),
 		jobPlanTable:      make(map[string]*common.JobsSchedulerPlan),
-		jobExecutingTable: make(map[string]*common.JobExecuteInfo),
+		jobExecutingTable: make(map[string][]*common.JobExecuteInfo),
 		jobResultChan:     make(chan *common.JobExecuteResult, 1000),
+		retryChan:         make(chan *common.JobExecuteInfo, 1000),
+		depWaitSince:      make(map[string]time.Time),
 	}
 	//  启动调度协程
 	go G_scheduler.schedulerLoop()